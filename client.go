@@ -1,11 +1,11 @@
 package chatgptclient
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	"github.com/go-zoox/core-utils/strings"
-	"github.com/go-zoox/lru"
 
 	openai "github.com/go-zoox/openai-client"
 )
@@ -13,6 +13,9 @@ import (
 // Client is the ChatGPT Client.
 type Client interface {
 	Ask(cfg *AskConfig) ([]byte, error)
+	// AskStream is the streaming counterpart of Ask: it returns a channel
+	// of incremental chunks instead of buffering the whole reply.
+	AskStream(cfg *AskConfig) (<-chan AskStreamChunk, error)
 	//
 	GetOrCreateConversation(id string, cfg *ConversationConfig) (Conversation, error)
 	//
@@ -27,7 +30,7 @@ type client struct {
 	core openai.Client
 	cfg  *Config
 	//
-	conversationsCache *lru.LRU
+	conversationStore ConversationStore
 }
 
 // Config is the configuration for the ChatGPT Client.
@@ -42,6 +45,15 @@ type Config struct {
 	ConversationLanguage string `json:"conversation_language"`
 	ChatGPTName          string `json:"chatgpt_name"`
 
+	// Tokenizer counts prompt tokens for trimming/packing decisions.
+	// Defaults to a tiktoken-go based implementation keyed by model.
+	Tokenizer Tokenizer `json:"-"`
+
+	// ConversationStore persists conversations. Defaults to an in-memory
+	// LRU, capped to MaxConversations; pass e.g. a RedisConversationStore
+	// to survive restarts and scale beyond a single node.
+	ConversationStore ConversationStore `json:"-"`
+
 	// Proxy sets the request proxy.
 	//
 	//	support http, https, socks5
@@ -59,6 +71,44 @@ type AskConfig struct {
 	Messages []*Message `json:"messages"`
 	//
 	MaxRequestResponseTokens int `json:"max_request_response_tokens"`
+
+	// Context controls cancellation. AskStream, and Ask when it sends Tools
+	// or Attachments, thread it into the HTTP request; otherwise Ask only
+	// checks it up front (the vendored openai client's
+	// CreateChatCompletion/CreateCompletion take no context.Context, so a
+	// cancellation mid-flight isn't honored, only one observed before the
+	// request is sent).
+	Context context.Context `json:"-"`
+
+	// PromptContext primes buildPrompt's legacy completion-style prompt
+	// (see Conversation's ConversationConfig.Context) for non-chat models.
+	// Ignored for chat-capable models, which send Messages directly.
+	PromptContext string `json:"-"`
+
+	// Tools lists the functions the model may call; ToolChoice controls
+	// whether/which one it must use: "auto", "none", "required", or a
+	// function name to force that specific function (serialized into the
+	// {"type":"function","function":{"name":...}} object form the API
+	// requires for that case).
+	Tools      []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice string           `json:"tool_choice,omitempty"`
+
+	// Result, if non-nil, is populated with the completion's metadata
+	// (finish reason, usage, tool calls) once Ask returns. Ask's signature
+	// can't grow a return value without breaking callers, so this is how
+	// that metadata is surfaced.
+	Result *AskResult `json:"-"`
+}
+
+// AskResult carries the completion metadata AskConfig.Result receives:
+// the finish reason, token usage and any tool calls that Ask would
+// otherwise discard.
+type AskResult struct {
+	FinishReason     string     `json:"finish_reason"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // New creates a new ChatGPT Client.
@@ -79,6 +129,18 @@ func New(cfg *Config) (Client, error) {
 		cfg.ChatGPTName = "ChatGPT"
 	}
 
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = newDefaultTokenizer()
+	}
+
+	if cfg.ConversationStore == nil {
+		cfg.ConversationStore = newLRUConversationStore(cfg.MaxConversations)
+	}
+
+	if cfg.APIServer == "" {
+		cfg.APIServer = DefaultAPIServer
+	}
+
 	core, err := openai.New(&openai.Config{
 		APIKey:    cfg.APIKey,
 		APIServer: cfg.APIServer,
@@ -89,9 +151,9 @@ func New(cfg *Config) (Client, error) {
 	}
 
 	return &client{
-		core:               core,
-		cfg:                cfg,
-		conversationsCache: lru.New(cfg.MaxConversations),
+		core:              core,
+		cfg:               cfg,
+		conversationStore: cfg.ConversationStore,
 	}, nil
 }
 
@@ -99,36 +161,75 @@ func (c *client) Ask(cfg *AskConfig) (answer []byte, err error) {
 	// numTokens := float64(len(question))
 	// maxTokens := math.Max(float64(c.cfg.MaxResponseTokens), math.Min(openai.MaxTokens-numTokens, float64(c.cfg.MaxResponseTokens)))
 
-	switch cfg.Model {
-	case openai.ModelGPT3_5Turbo, openai.ModelGPT3_5Turbo0301:
+	if cfg.Context != nil {
+		if err := cfg.Context.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isChatModel(cfg.Model) && len(cfg.Tools) > 0 {
+		return nil, fmt.Errorf("model %q does not support Tools; use a chat-capable model instead", cfg.Model)
+	}
+
+	if isChatModel(cfg.Model) {
 		// chat
-		currentMessageLength := 0
+		promptTokens := 0
 		messages := []openai.CreateChatCompletionMessage{}
 		for _, msg := range cfg.Messages {
-			currentMessageLength += len(msg.Text)
+			tokens, err := c.cfg.Tokenizer.CountTokens(cfg.Model, msg.Text)
+			if err != nil {
+				return nil, err
+			}
+
+			promptTokens += tokens
 			messages = append(messages, openai.CreateChatCompletionMessage{
 				Role:    msg.Role,
 				Content: msg.Text,
 			})
 		}
 
-		maxTokens := calculationPromptMaxTokens(currentMessageLength, cfg.MaxRequestResponseTokens, c.cfg.MaxResponseTokens)
+		maxTokens := calculationPromptMaxTokens(promptTokens, modelContextWindow(cfg.Model), c.cfg.MaxResponseTokens)
+
+		if hasAttachments(cfg.Messages) || len(cfg.Tools) > 0 {
+			return c.askChatRaw(cfg, maxTokens)
+		}
+
 		completion, err := c.core.CreateChatCompletion(&openai.CreateChatCompletionRequest{
-			Model:     cfg.Model,
-			Messages:  messages,
-			MaxTokens: maxTokens,
+			Model:       cfg.Model,
+			Messages:    messages,
+			MaxTokens:   maxTokens,
 			Temperature: 0.1,
 		})
 		if err != nil {
 			return nil, err
 		}
 
+		if cfg.Result != nil {
+			cfg.Result.FinishReason = completion.Choices[0].FinishReason
+			cfg.Result.PromptTokens = completion.Usage.PromptTokens
+			cfg.Result.CompletionTokens = completion.Usage.CompletionTokens
+			cfg.Result.TotalTokens = completion.Usage.TotalTokens
+		}
+
 		return []byte(strings.TrimSpace(completion.Choices[0].Message.Content)), nil
 	}
 
 	// prompt
 	questionX := cfg.Prompt
-	maxTokens := calculationPromptMaxTokens(len(questionX), cfg.MaxRequestResponseTokens, c.cfg.MaxResponseTokens)
+	if len(cfg.Messages) > 0 {
+		built, err := buildPromptFromMessages(c.cfg.Tokenizer, cfg.Model, cfg.PromptContext, cfg.Messages, c.cfg.MaxResponseTokens)
+		if err != nil {
+			return nil, err
+		}
+		questionX = string(built)
+	}
+
+	promptTokens, err := c.cfg.Tokenizer.CountTokens(cfg.Model, questionX)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := calculationPromptMaxTokens(promptTokens, modelContextWindow(cfg.Model), c.cfg.MaxResponseTokens)
 
 	completion, err := c.core.CreateCompletion(&openai.CreateCompletionRequest{
 		Model:     cfg.Model,
@@ -139,10 +240,17 @@ func (c *client) Ask(cfg *AskConfig) (answer []byte, err error) {
 		return nil, err
 	}
 
+	if cfg.Result != nil {
+		cfg.Result.FinishReason = completion.Choices[0].FinishReason
+		cfg.Result.PromptTokens = completion.Usage.PromptTokens
+		cfg.Result.CompletionTokens = completion.Usage.CompletionTokens
+		cfg.Result.TotalTokens = completion.Usage.TotalTokens
+	}
+
 	return []byte(strings.TrimSpace(completion.Choices[0].Text)), nil
 }
 
-func (c *client) GetOrCreateConversation(id string, cfg *ConversationConfig) (conversation Conversation, err error) {
+func (c *client) GetOrCreateConversation(id string, cfg *ConversationConfig) (conv Conversation, err error) {
 	if cfg.ID == "" {
 		cfg.ID = id
 	}
@@ -159,40 +267,37 @@ func (c *client) GetOrCreateConversation(id string, cfg *ConversationConfig) (co
 		cfg.ChatGPTName = c.cfg.ChatGPTName
 	}
 
-	if cache, ok := c.conversationsCache.Get(cfg.ID); ok {
-		if c, ok := cache.(Conversation); ok {
-			conversation = c
-			return conversation, nil
-		}
+	if cached, ok := c.conversationStore.Get(cfg.ID); ok {
+		conv = cached
+		return conv, nil
 	}
 
-	conversation, err = NewConversation(c, cfg)
+	conv, err = NewConversation(c, cfg)
 	if err != nil {
 		return nil, err
 	}
+	if impl, ok := conv.(*conversation); ok {
+		impl.store = c.conversationStore
+	}
 
-	c.conversationsCache.Set(id, conversation, cfg.MaxAge)
+	if err := c.conversationStore.Set(id, conv, cfg.MaxAge); err != nil {
+		return nil, err
+	}
 
-	return conversation, nil
+	return conv, nil
 }
 
 func (c *client) ResetConversations() error {
-	c.conversationsCache.Clear()
-
-	return nil
+	return c.conversationStore.Clear()
 }
 
 func (c *client) ResetConversation(id string) error {
-	c.conversationsCache.Delete(id)
-
-	return nil
+	return c.conversationStore.Delete(id)
 }
 
 func (c *client) GetConversation(id string) (conversation Conversation, err error) {
-	if cache, ok := c.conversationsCache.Get(id); ok {
-		if c, ok := cache.(Conversation); ok {
-			return c, nil
-		}
+	if cached, ok := c.conversationStore.Get(id); ok {
+		return cached, nil
 	}
 
 	return nil, fmt.Errorf("conversation(id: %s) not found", id)
@@ -207,9 +312,16 @@ func (c *client) ChangeConversationModel(conversationID string, model string) er
 	return conversation.SetModel(model)
 }
 
-func calculationPromptMaxTokens(questLength, MaxRequestResponseTokens, MaxResponseTokens int) int {
-	numTokens := questLength
-	maxTokens := math.Max(float64(MaxResponseTokens), math.Min(float64(MaxRequestResponseTokens-numTokens), float64(MaxResponseTokens)))
+// calculationPromptMaxTokens sizes the response budget for a request given
+// its real (tiktoken-counted) prompt token count and the model's context
+// window, keyed by model rather than the raw character length of the
+// prompt: min(maxResponseTokens, contextWindow-promptTokens), floored at 0
+// once the prompt itself has eaten the whole window.
+func calculationPromptMaxTokens(promptTokens, contextWindow, maxResponseTokens int) int {
+	headroom := contextWindow - promptTokens
+	if headroom <= 0 {
+		return 0
+	}
 
-	return int(maxTokens)
+	return int(math.Min(float64(headroom), float64(maxResponseTokens)))
 }
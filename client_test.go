@@ -0,0 +1,26 @@
+package chatgptclient
+
+import "testing"
+
+func TestCalculationPromptMaxTokens(t *testing.T) {
+	tests := []struct {
+		name              string
+		promptTokens      int
+		contextWindow     int
+		maxResponseTokens int
+		want              int
+	}{
+		{"plenty of headroom", 100, 4096, 1000, 1000},
+		{"headroom smaller than max response", 3500, 4096, 1000, 596},
+		{"prompt fills the window", 4096, 4096, 1000, 0},
+		{"prompt overflows the window", 5000, 4096, 1000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculationPromptMaxTokens(tt.promptTokens, tt.contextWindow, tt.maxResponseTokens); got != tt.want {
+				t.Errorf("calculationPromptMaxTokens(%d, %d, %d) = %d, want %d", tt.promptTokens, tt.contextWindow, tt.maxResponseTokens, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package chatgptclient
+
+import "time"
+
+// DefaultMaxResponseTokens is the response token budget used when
+// Config.MaxResponseTokens is left unset.
+const DefaultMaxResponseTokens = 1000
+
+// DefaultMaxConversations bounds the in-memory LRU's size when
+// Config.MaxConversations is left unset.
+const DefaultMaxConversations = 10000
+
+// DefaultConversationMaxAge is how long a conversation is kept when
+// ConversationConfig.MaxAge is left unset.
+const DefaultConversationMaxAge = 30 * 24 * time.Hour
+
+// DefaultAPIServer is the OpenAI API server used when Config.APIServer is
+// left unset. The vendored openai.Client defaults its own copy of this URL
+// internally, but AskStream and askChatRaw bypass it and hit c.cfg.APIServer
+// directly, so this package needs the same default.
+const DefaultAPIServer = "https://api.openai.com"
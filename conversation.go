@@ -0,0 +1,219 @@
+package chatgptclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-zoox/core-utils/safe"
+)
+
+// DefaultMaxContinuations bounds how many times Conversation.Continue can
+// resume a single turn that keeps hitting max_tokens.
+const DefaultMaxContinuations = 3
+
+// ConversationConfig is the configuration for a Conversation.
+type ConversationConfig struct {
+	ID          string        `json:"id"`
+	Model       string        `json:"model"`
+	MaxAge      time.Duration `json:"max_age"`
+	Context     string        `json:"context"`
+	Language    string        `json:"language"`
+	ChatGPTName string        `json:"chatgpt_name"`
+
+	// MaxContinuations bounds Conversation.Continue calls for a single
+	// turn. Defaults to DefaultMaxContinuations.
+	MaxContinuations int `json:"max_continuations"`
+}
+
+// Conversation is a stateful, multi-turn chat session bound to a single
+// conversation ID. It replays its own history on every Ask so the model
+// keeps context across turns.
+type Conversation interface {
+	Ask(question string) ([]byte, error)
+	// AskStream is the streaming counterpart of Ask.
+	AskStream(cfg *AskConfig) (<-chan AskStreamChunk, error)
+	//
+	SetModel(model string) error
+
+	// Continue re-issues the last turn when it was cut off by max_tokens
+	// (finish_reason == "length"), stitching the new fragment onto the
+	// stored assistant message rather than starting a new turn.
+	Continue(ctx context.Context) ([]byte, error)
+
+	// AskWithTools is the agentic counterpart of Ask: it loops calling the
+	// model and dispatching any tool_calls to executor until a final
+	// assistant message arrives or maxToolIterations is hit (0 uses
+	// DefaultMaxToolIterations).
+	AskWithTools(ctx context.Context, question string, tools []ToolDefinition, executor ToolExecutor, maxToolIterations int) ([]byte, error)
+
+	// Config returns the conversation's configuration, e.g. for a
+	// ConversationStore to persist alongside its history.
+	Config() *ConversationConfig
+	// History snapshots the conversation's messages in chronological
+	// order, e.g. for a ConversationStore to serialize.
+	History() []*Message
+
+	// LastResult returns the metadata (finish reason, usage, tool calls) of
+	// the most recent Ask/AskStream/AskWithTools turn, or nil if none has
+	// completed yet.
+	LastResult() *AskResult
+}
+
+type conversation struct {
+	client *client
+	cfg    *ConversationConfig
+	// store is the ConversationStore this conversation was handed out by,
+	// re-Set after every turn so backends that don't share memory with
+	// Get/Set (e.g. Redis) see mutations made after creation. Nil for a
+	// Conversation constructed directly rather than via
+	// Client.GetOrCreateConversation.
+	store ConversationStore
+	//
+	messages *safe.List
+	//
+	lastAssistantMessage *Message
+	lastResult           *AskResult
+	continuations        int
+}
+
+// NewConversation creates a new Conversation bound to the given client.
+func NewConversation(c *client, cfg *ConversationConfig) (Conversation, error) {
+	return &conversation{
+		client:   c,
+		cfg:      cfg,
+		messages: safe.NewList(),
+	}, nil
+}
+
+// NewConversationFromHistory rehydrates a Conversation bound to the given
+// client, restoring previously persisted messages. ConversationStore
+// implementations that survive process restarts (e.g. Redis) use this to
+// reconstruct a Conversation on Get.
+func NewConversationFromHistory(c *client, cfg *ConversationConfig, messages []*Message) (Conversation, error) {
+	cv := &conversation{
+		client:   c,
+		cfg:      cfg,
+		messages: safe.NewList(),
+	}
+
+	for _, message := range messages {
+		cv.messages.Push(message)
+	}
+
+	return cv, nil
+}
+
+// persist re-Sets the conversation on its owning store, if any, so a store
+// that serializes history on Set (e.g. Redis) observes turns made after
+// creation rather than only the empty history GetOrCreateConversation saw
+// at Set time.
+func (cv *conversation) persist() error {
+	if cv.store == nil {
+		return nil
+	}
+
+	return cv.store.Set(cv.cfg.ID, cv, cv.cfg.MaxAge)
+}
+
+func (cv *conversation) Ask(question string) ([]byte, error) {
+	cv.messages.Push(&Message{Role: "user", Text: question})
+
+	result := &AskResult{}
+	answer, err := cv.client.Ask(&AskConfig{
+		Model:         cv.cfg.Model,
+		Prompt:        question,
+		Messages:      cv.history(),
+		PromptContext: cv.cfg.Context,
+		Result:        result,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	assistantMessage := &Message{Role: "assistant", Text: string(answer), IsChatGPT: true}
+	cv.messages.Push(assistantMessage)
+	cv.lastAssistantMessage = assistantMessage
+	cv.lastResult = result
+	cv.continuations = 0
+
+	if err := cv.persist(); err != nil {
+		return nil, err
+	}
+
+	return answer, nil
+}
+
+// Continue resumes the last turn when it was cut off by max_tokens. It
+// re-issues the request with the partial reply appended as an assistant
+// message plus a system instruction to resume seamlessly, then stitches
+// the new fragment onto the stored assistant message.
+func (cv *conversation) Continue(ctx context.Context) ([]byte, error) {
+	if cv.lastAssistantMessage == nil || cv.lastResult == nil || cv.lastResult.FinishReason != "length" {
+		return nil, fmt.Errorf("conversation(id: %s): nothing to continue", cv.cfg.ID)
+	}
+
+	maxContinuations := cv.cfg.MaxContinuations
+	if maxContinuations == 0 {
+		maxContinuations = DefaultMaxContinuations
+	}
+	if cv.continuations >= maxContinuations {
+		return nil, fmt.Errorf("conversation(id: %s): reached MaxContinuations(%d)", cv.cfg.ID, maxContinuations)
+	}
+
+	history := append(cv.history(), &Message{
+		Role: "system",
+		Text: "Continue your previous reply exactly where it left off, with no repetition or preamble.",
+	})
+
+	result := &AskResult{}
+	fragment, err := cv.client.Ask(&AskConfig{
+		Model:         cv.cfg.Model,
+		Messages:      history,
+		Context:       ctx,
+		PromptContext: cv.cfg.Context,
+		Result:        result,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cv.lastAssistantMessage.Text += string(fragment)
+	cv.lastResult = result
+	cv.continuations++
+
+	if err := cv.persist(); err != nil {
+		return nil, err
+	}
+
+	return []byte(cv.lastAssistantMessage.Text), nil
+}
+
+func (cv *conversation) SetModel(model string) error {
+	cv.cfg.Model = model
+
+	return cv.persist()
+}
+
+func (cv *conversation) Config() *ConversationConfig {
+	return cv.cfg
+}
+
+func (cv *conversation) History() []*Message {
+	return cv.history()
+}
+
+func (cv *conversation) LastResult() *AskResult {
+	return cv.lastResult
+}
+
+// history snapshots the conversation's messages in chronological order.
+func (cv *conversation) history() (messages []*Message) {
+	cv.messages.ForEach(func(i interface{}) (done bool) {
+		messages = append(messages, i.(*Message))
+
+		return false
+	})
+
+	return messages
+}
@@ -0,0 +1,24 @@
+package chatgptclient
+
+// Message is a single turn in a conversation's history.
+type Message struct {
+	// Role is the OpenAI role for this turn: system, user or assistant.
+	Role string `json:"role"`
+	// Text is the message content.
+	Text string `json:"text"`
+	// User is the display name of the human participant, if any.
+	User string `json:"user,omitempty"`
+	// IsChatGPT marks a message as having been generated by ChatGPT.
+	IsChatGPT bool `json:"is_chat_gpt,omitempty"`
+	// Attachments carries non-text parts (images, files) for vision-capable
+	// models. Legacy text prompts (see buildPrompt) skip them.
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// ToolCalls is set on an assistant message that asked to call one or
+	// more tools.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID and Name identify which ToolCall a "tool" role message is
+	// the result of.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
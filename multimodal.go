@@ -0,0 +1,184 @@
+package chatgptclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AttachmentType distinguishes how an Attachment's content should be read.
+type AttachmentType string
+
+const (
+	// AttachmentTypeImageURL references a hosted image by URL.
+	AttachmentTypeImageURL AttachmentType = "image_url"
+	// AttachmentTypeImageBase64 carries an inline base64-encoded image.
+	AttachmentTypeImageBase64 AttachmentType = "image_base64"
+)
+
+// Attachment is a non-text part of a Message: an image URL or an inline
+// base64-encoded image. Messages carrying attachments are serialized to
+// OpenAI's vision content-parts schema for chat-capable models and have
+// their binary parts skipped when composing legacy text prompts.
+type Attachment struct {
+	Type AttachmentType `json:"type"`
+	// URL is set for AttachmentTypeImageURL.
+	URL string `json:"url,omitempty"`
+	// Base64 is set for AttachmentTypeImageBase64 (no data: URI prefix).
+	Base64 string `json:"base64,omitempty"`
+	// MimeType describes Base64's content, e.g. "image/png".
+	MimeType string `json:"mime_type,omitempty"`
+}
+
+type chatContentPart struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	ImageURL *chatContentImage `json:"image_url,omitempty"`
+}
+
+type chatContentImage struct {
+	URL string `json:"url"`
+}
+
+// hasAttachments reports whether any message in the conversation carries
+// an Attachment, meaning it must go through the vision content-parts path.
+func hasAttachments(messages []*Message) bool {
+	for _, msg := range messages {
+		if len(msg.Attachments) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// buildChatContent serializes a Message to either a plain string (the
+// common case) or OpenAI's vision content-parts schema when it carries
+// attachments.
+func buildChatContent(msg *Message) (interface{}, error) {
+	if len(msg.Attachments) == 0 {
+		return msg.Text, nil
+	}
+
+	parts := []chatContentPart{}
+	if msg.Text != "" {
+		parts = append(parts, chatContentPart{Type: "text", Text: msg.Text})
+	}
+
+	for _, attachment := range msg.Attachments {
+		switch attachment.Type {
+		case AttachmentTypeImageURL:
+			parts = append(parts, chatContentPart{Type: "image_url", ImageURL: &chatContentImage{URL: attachment.URL}})
+		case AttachmentTypeImageBase64:
+			parts = append(parts, chatContentPart{
+				Type:     "image_url",
+				ImageURL: &chatContentImage{URL: fmt.Sprintf("data:%s;base64,%s", attachment.MimeType, attachment.Base64)},
+			})
+		}
+	}
+
+	return parts, nil
+}
+
+// askChatRaw sends a chat/completions request carrying whatever the
+// vendored openai client's CreateChatCompletionMessage can't express:
+// vision content-parts (attachments) and/or function/tool calling.
+func (c *client) askChatRaw(cfg *AskConfig, maxTokens int) ([]byte, error) {
+	type chatMessage struct {
+		Role       string      `json:"role"`
+		Content    interface{} `json:"content,omitempty"`
+		ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+		ToolCallID string      `json:"tool_call_id,omitempty"`
+		Name       string      `json:"name,omitempty"`
+	}
+
+	messages := make([]chatMessage, 0, len(cfg.Messages))
+	for _, msg := range cfg.Messages {
+		content, err := buildChatContent(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, chatMessage{
+			Role:       msg.Role,
+			Content:    content,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.Name,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"model":       cfg.Model,
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": 0.1,
+	}
+	if len(cfg.Tools) > 0 {
+		payload["tools"] = cfg.Tools
+	}
+	if cfg.ToolChoice != "" {
+		payload["tool_choice"] = toolChoicePayload(cfg.ToolChoice)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.APIServer+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openai chat request failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content   string     `json:"content"`
+				ToolCalls []ToolCall `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("openai chat response had no choices")
+	}
+
+	if cfg.Result != nil {
+		cfg.Result.FinishReason = result.Choices[0].FinishReason
+		cfg.Result.PromptTokens = result.Usage.PromptTokens
+		cfg.Result.CompletionTokens = result.Usage.CompletionTokens
+		cfg.Result.TotalTokens = result.Usage.TotalTokens
+		cfg.Result.ToolCalls = result.Choices[0].Message.ToolCalls
+	}
+
+	return []byte(strings.TrimSpace(result.Choices[0].Message.Content)), nil
+}
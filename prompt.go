@@ -2,20 +2,32 @@ package chatgptclient
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/go-zoox/core-utils/safe"
 )
 
-func buildPrompt(context, date string, messages *safe.List, maxLength int) (prompt []byte, err error) {
+// buildPrompt assembles the legacy completion-style prompt, packing
+// messages newest-first and dropping the oldest ones once the prompt would
+// no longer leave maxResponseTokens of headroom in the model's context
+// window. Token counts come from tokenizer rather than len(string), so
+// non-ASCII (e.g. CJK) messages are budgeted correctly.
+func buildPrompt(tokenizer Tokenizer, model, context, date string, messages *safe.List, maxResponseTokens int) (prompt []byte, err error) {
 	contextMessage := fmt.Sprintf("%s\nCurrent date: %s", context, date)
 	endMessage := "ChatGPT:"
 	endOfText := "<|endoftext|>\n\n"
 
-	charCountRes := len(contextMessage) + len(endMessage)
+	contextWindow := modelContextWindow(model)
+
+	tokenCountRes, tokenErr := tokenizer.CountTokens(model, contextMessage+endMessage)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
 	coreMessages := ""
 
 	var currentMessage string
-	var currentTextLength int
+	var currentTokens int
 	messages.Reverse().ForEach(func(i interface{}) (done bool) {
 		message := i.(*Message)
 		if message.IsChatGPT {
@@ -28,16 +40,23 @@ func buildPrompt(context, date string, messages *safe.List, maxLength int) (prom
 			}
 		}
 
-		currentTextLength = len(currentMessage) + len(endOfText)
-		if maxLength > 0 && charCountRes+currentTextLength >= maxLength {
+		currentTokens, tokenErr = tokenizer.CountTokens(model, currentMessage+endOfText)
+		if tokenErr != nil {
+			return true
+		}
+
+		if tokenCountRes+currentTokens+maxResponseTokens > contextWindow {
 			return true
 		}
 
-		charCountRes += currentTextLength
+		tokenCountRes += currentTokens
 		coreMessages = fmt.Sprintf("%s%s%s", currentMessage, endOfText, coreMessages)
 
 		return false
 	})
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
 
 	// textMessages := append([]string{contextMessage}, array.Reverse(coreMessages)...)
 	// textMessages = append(textMessages, chat)
@@ -47,3 +66,16 @@ func buildPrompt(context, date string, messages *safe.List, maxLength int) (prom
 	message := fmt.Sprintf("%s%s%s%s", contextMessage, endOfText, coreMessages, endMessage)
 	return []byte(message), nil
 }
+
+// buildPromptFromMessages packs messages into a *safe.List and delegates to
+// buildPrompt. It's how Client.Ask turns AskConfig.Messages into a single
+// legacy completion-style prompt for models that don't have a chat
+// endpoint.
+func buildPromptFromMessages(tokenizer Tokenizer, model, context string, messages []*Message, maxResponseTokens int) ([]byte, error) {
+	list := safe.NewList()
+	for _, message := range messages {
+		list.Push(message)
+	}
+
+	return buildPrompt(tokenizer, model, context, time.Now().Format("2006-01-02"), list, maxResponseTokens)
+}
@@ -0,0 +1,110 @@
+package chatgptclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConversationStore is a reference ConversationStore backed by Redis,
+// so conversations survive process restarts and can be shared across a
+// horizontally-scaled gateway. Each conversation's config and message
+// history are serialized as JSON under "chatgpt:conversation:<id>".
+type RedisConversationStore struct {
+	client *client
+	redis  *redis.Client
+	prefix string
+}
+
+// NewRedisConversationStore creates a RedisConversationStore. c must be a
+// Client created via New, since conversations are reconstructed against it
+// on Get.
+func NewRedisConversationStore(c Client, redisClient *redis.Client) (*RedisConversationStore, error) {
+	cc, ok := c.(*client)
+	if !ok {
+		return nil, fmt.Errorf("NewRedisConversationStore requires a *client created via New")
+	}
+
+	return &RedisConversationStore{client: cc, redis: redisClient, prefix: "chatgpt:conversation:"}, nil
+}
+
+type redisConversationRecord struct {
+	Config   *ConversationConfig `json:"config"`
+	Messages []*Message          `json:"messages"`
+}
+
+func (s *RedisConversationStore) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *RedisConversationStore) Get(id string) (Conversation, bool) {
+	data, err := s.redis.Get(context.Background(), s.key(id)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	var record redisConversationRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false
+	}
+
+	conv, err := NewConversationFromHistory(s.client, record.Config, record.Messages)
+	if err != nil {
+		return nil, false
+	}
+	if impl, ok := conv.(*conversation); ok {
+		impl.store = s
+	}
+
+	return conv, true
+}
+
+func (s *RedisConversationStore) Set(id string, conversation Conversation, maxAge time.Duration) error {
+	record := redisConversationRecord{
+		Config:   conversation.Config(),
+		Messages: conversation.History(),
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Set(context.Background(), s.key(id), data, maxAge).Err()
+}
+
+func (s *RedisConversationStore) Delete(id string) error {
+	return s.redis.Del(context.Background(), s.key(id)).Err()
+}
+
+func (s *RedisConversationStore) Clear() error {
+	ids, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.Delete(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *RedisConversationStore) List() ([]string, error) {
+	keys, err := s.redis.Keys(context.Background(), s.prefix+"*").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key[len(s.prefix):])
+	}
+
+	return ids, nil
+}
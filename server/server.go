@@ -0,0 +1,346 @@
+// Package server exposes an HTTP service that imitates the OpenAI
+// chat/completions and completions APIs on top of a chatgptclient.Client,
+// so this module can sit as a drop-in gateway in front of upstream OpenAI.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	chatgptclient "github.com/lifecoder1988/chatgpt-client"
+)
+
+// ServerConfig is the configuration for Serve.
+type ServerConfig struct {
+	// Addr is the address to listen on, e.g. ":8080".
+	Addr string `json:"addr"`
+	// AccessTokens is the allowlist of bearer tokens accepted on requests.
+	// If empty, auth is disabled.
+	AccessTokens []string `json:"access_tokens"`
+	// ConversationIDHeader is the header used to thread a conversation ID
+	// through repeated calls. Defaults to X-Conversation-ID.
+	ConversationIDHeader string `json:"conversation_id_header"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type completionRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type choiceMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionChoice struct {
+	Index        int           `json:"index"`
+	Message      choiceMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+type completionChoice struct {
+	Index        int    `json:"index"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type chatCompletionResponse struct {
+	Object  string                 `json:"object"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   usage                  `json:"usage"`
+}
+
+type completionResponse struct {
+	Object  string             `json:"object"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+	Usage   usage              `json:"usage"`
+}
+
+type server struct {
+	client chatgptclient.Client
+	cfg    ServerConfig
+}
+
+// Serve runs an HTTP service implementing the OpenAI-compatible
+// /v1/chat/completions and /v1/completions endpoints (both streaming and
+// non-streaming) on top of the given chatgptclient.Client.
+func Serve(c chatgptclient.Client, cfg ServerConfig) error {
+	if cfg.ConversationIDHeader == "" {
+		cfg.ConversationIDHeader = "X-Conversation-ID"
+	}
+
+	s := &server{client: c, cfg: cfg}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", s.withAuth(s.handleChatCompletions))
+	mux.HandleFunc("/v1/completions", s.withAuth(s.handleCompletions))
+
+	return http.ListenAndServe(cfg.Addr, mux)
+}
+
+func (s *server) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.cfg.AccessTokens) == 0 {
+			next(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for _, accessToken := range s.cfg.AccessTokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(accessToken)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid bearer token"))
+	}
+}
+
+func (s *server) conversationFor(r *http.Request, model string) (chatgptclient.Conversation, error) {
+	id := r.Header.Get(s.cfg.ConversationIDHeader)
+	if id == "" {
+		return nil, nil
+	}
+
+	return s.client.GetOrCreateConversation(id, &chatgptclient.ConversationConfig{
+		ID:    id,
+		Model: model,
+	})
+}
+
+func (s *server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	messages := make([]*chatgptclient.Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, &chatgptclient.Message{Role: m.Role, Text: m.Content})
+	}
+
+	conversation, err := s.conversationFor(r, req.Model)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	cfg := &chatgptclient.AskConfig{Model: req.Model, Messages: messages}
+
+	if req.Stream {
+		s.streamChatCompletion(w, r, conversation, cfg)
+		return
+	}
+
+	answer, result, err := s.ask(conversation, cfg)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &chatCompletionResponse{
+		Object: "chat.completion",
+		Model:  req.Model,
+		Choices: []chatCompletionChoice{
+			{Message: choiceMessage{Role: "assistant", Content: string(answer)}, FinishReason: result.FinishReason},
+		},
+		Usage: usage{
+			PromptTokens:     result.PromptTokens,
+			CompletionTokens: result.CompletionTokens,
+			TotalTokens:      result.TotalTokens,
+		},
+	})
+}
+
+func (s *server) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	var req completionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conversation, err := s.conversationFor(r, req.Model)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	cfg := &chatgptclient.AskConfig{Model: req.Model, Prompt: req.Prompt}
+
+	if req.Stream {
+		s.streamCompletion(w, r, conversation, cfg)
+		return
+	}
+
+	answer, result, err := s.ask(conversation, cfg)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &completionResponse{
+		Object:  "text_completion",
+		Model:   req.Model,
+		Choices: []completionChoice{{Text: string(answer), FinishReason: result.FinishReason}},
+		Usage: usage{
+			PromptTokens:     result.PromptTokens,
+			CompletionTokens: result.CompletionTokens,
+			TotalTokens:      result.TotalTokens,
+		},
+	})
+}
+
+func (s *server) ask(conversation chatgptclient.Conversation, cfg *chatgptclient.AskConfig) ([]byte, *chatgptclient.AskResult, error) {
+	if conversation != nil {
+		question := cfg.Prompt
+		if question == "" && len(cfg.Messages) > 0 {
+			question = cfg.Messages[len(cfg.Messages)-1].Text
+		}
+
+		answer, err := conversation.Ask(question)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return answer, conversation.LastResult(), nil
+	}
+
+	result := &chatgptclient.AskResult{}
+	cfg.Result = result
+
+	answer, err := s.client.Ask(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return answer, result, nil
+}
+
+func (s *server) streamChatCompletion(w http.ResponseWriter, r *http.Request, conversation chatgptclient.Conversation, cfg *chatgptclient.AskConfig) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	cfg.Context = r.Context()
+
+	var out <-chan chatgptclient.AskStreamChunk
+	var err error
+	if conversation != nil {
+		if cfg.Prompt == "" && len(cfg.Messages) > 0 {
+			cfg.Prompt = cfg.Messages[len(cfg.Messages)-1].Text
+		}
+		out, err = conversation.AskStream(cfg)
+	} else {
+		out, err = s.client.AskStream(cfg)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	for chunk := range out {
+		if chunk.Err != nil {
+			break
+		}
+
+		fmt.Fprintf(w, "data: %s\n\n", mustJSON(&chatCompletionResponse{
+			Object: "chat.completion.chunk",
+			Model:  cfg.Model,
+			Choices: []chatCompletionChoice{
+				{Message: choiceMessage{Content: chunk.Delta}, FinishReason: chunk.FinishReason},
+			},
+		}))
+		flusher.Flush()
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func (s *server) streamCompletion(w http.ResponseWriter, r *http.Request, conversation chatgptclient.Conversation, cfg *chatgptclient.AskConfig) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	cfg.Context = r.Context()
+
+	var out <-chan chatgptclient.AskStreamChunk
+	var err error
+	if conversation != nil {
+		out, err = conversation.AskStream(cfg)
+	} else {
+		out, err = s.client.AskStream(cfg)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	for chunk := range out {
+		if chunk.Err != nil {
+			break
+		}
+
+		fmt.Fprintf(w, "data: %s\n\n", mustJSON(&completionResponse{
+			Object:  "text_completion",
+			Model:   cfg.Model,
+			Choices: []completionChoice{{Text: chunk.Delta, FinishReason: chunk.FinishReason}},
+		}))
+		flusher.Flush()
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"message": err.Error()},
+	})
+}
+
+func mustJSON(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
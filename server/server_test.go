@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chatgptclient "github.com/lifecoder1988/chatgpt-client"
+)
+
+// fakeClient is a minimal chatgptclient.Client stand-in for wiring tests:
+// it never talks to OpenAI, just echoes back canned answers.
+type fakeClient struct{}
+
+func (f *fakeClient) Ask(cfg *chatgptclient.AskConfig) ([]byte, error) {
+	if cfg.Result != nil {
+		cfg.Result.FinishReason = "stop"
+		cfg.Result.TotalTokens = 7
+	}
+
+	return []byte("fake answer"), nil
+}
+
+func (f *fakeClient) AskStream(cfg *chatgptclient.AskConfig) (<-chan chatgptclient.AskStreamChunk, error) {
+	ch := make(chan chatgptclient.AskStreamChunk, 2)
+	ch <- chatgptclient.AskStreamChunk{Delta: "fake "}
+	ch <- chatgptclient.AskStreamChunk{Delta: "answer", FinishReason: "stop"}
+	close(ch)
+
+	return ch, nil
+}
+
+func (f *fakeClient) GetOrCreateConversation(id string, cfg *chatgptclient.ConversationConfig) (chatgptclient.Conversation, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) ResetConversations() error                                         { return nil }
+func (f *fakeClient) ResetConversation(id string) error                                 { return nil }
+func (f *fakeClient) ChangeConversationModel(conversationID string, model string) error { return nil }
+
+func newTestServer() *server {
+	return &server{client: &fakeClient{}, cfg: ServerConfig{ConversationIDHeader: "X-Conversation-ID"}}
+}
+
+func TestHandleChatCompletionsNonStreaming(t *testing.T) {
+	s := newTestServer()
+
+	body := strings.NewReader(`{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"hi"}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", body)
+	w := httptest.NewRecorder()
+
+	s.handleChatCompletions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp chatCompletionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "fake answer" {
+		t.Errorf("resp.Choices = %+v, want a single choice with content %q", resp.Choices, "fake answer")
+	}
+	if resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("resp.Choices[0].FinishReason = %q, want %q", resp.Choices[0].FinishReason, "stop")
+	}
+}
+
+func TestHandleChatCompletionsStreaming(t *testing.T) {
+	s := newTestServer()
+
+	body := strings.NewReader(`{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"hi"}],"stream":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", body)
+	w := httptest.NewRecorder()
+
+	s.handleChatCompletions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	got := w.Body.String()
+	if !strings.Contains(got, `"content":"fake "`) || !strings.Contains(got, `"content":"answer"`) {
+		t.Errorf("streamed body = %q, want it to contain both deltas", got)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(got), "data: [DONE]") {
+		t.Errorf("streamed body = %q, want it to end with the [DONE] sentinel", got)
+	}
+}
+
+func TestWithAuthRejectsBadToken(t *testing.T) {
+	s := &server{client: &fakeClient{}, cfg: ServerConfig{AccessTokens: []string{"secret"}}}
+
+	called := false
+	handler := s.withAuth(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if called {
+		t.Error("handler was called with an invalid bearer token")
+	}
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestWithAuthAcceptsGoodToken(t *testing.T) {
+	s := &server{client: &fakeClient{}, cfg: ServerConfig{AccessTokens: []string{"secret"}}}
+
+	called := false
+	handler := s.withAuth(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if !called {
+		t.Error("handler was not called with a valid bearer token")
+	}
+}
@@ -0,0 +1,99 @@
+package chatgptclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-zoox/lru"
+)
+
+// ConversationStore persists Conversations so GetOrCreateConversation can
+// survive restarts and scale beyond a single node and process. The
+// default, used when Config.ConversationStore is nil, is an in-memory LRU
+// (see newLRUConversationStore); Redis, BoltDB or SQL backed stores can be
+// plugged in instead.
+type ConversationStore interface {
+	Get(id string) (Conversation, bool)
+	Set(id string, conversation Conversation, maxAge time.Duration) error
+	Delete(id string) error
+	Clear() error
+	List() ([]string, error)
+}
+
+type lruConversationStore struct {
+	cache *lru.LRU
+	//
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+// newLRUConversationStore is the default ConversationStore: an in-process
+// LRU cache. It caps scale to a single node and process, and does not
+// survive restarts.
+func newLRUConversationStore(maxConversations int) ConversationStore {
+	return &lruConversationStore{
+		cache: lru.New(maxConversations),
+		ids:   map[string]struct{}{},
+	}
+}
+
+func (s *lruConversationStore) Get(id string) (Conversation, bool) {
+	cache, ok := s.cache.Get(id)
+	if !ok {
+		return nil, false
+	}
+
+	conversation, ok := cache.(Conversation)
+
+	return conversation, ok
+}
+
+func (s *lruConversationStore) Set(id string, conversation Conversation, maxAge time.Duration) error {
+	s.cache.Set(id, conversation, maxAge)
+
+	s.mu.Lock()
+	s.ids[id] = struct{}{}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *lruConversationStore) Delete(id string) error {
+	s.cache.Delete(id)
+
+	s.mu.Lock()
+	delete(s.ids, id)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *lruConversationStore) Clear() error {
+	s.cache.Clear()
+
+	s.mu.Lock()
+	s.ids = map[string]struct{}{}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// List returns the IDs of conversations still present in the underlying
+// LRU, pruning any that it has since evicted so the id set doesn't grow
+// unbounded in a long-lived process.
+func (s *lruConversationStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		if _, ok := s.cache.Get(id); !ok {
+			delete(s.ids, id)
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,55 @@
+package chatgptclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// stubConversation is a minimal Conversation used only to exercise
+// ConversationStore without going through a real client.
+type stubConversation struct{}
+
+func (stubConversation) Ask(question string) ([]byte, error) { return nil, nil }
+func (stubConversation) AskStream(cfg *AskConfig) (<-chan AskStreamChunk, error) {
+	return nil, nil
+}
+func (stubConversation) SetModel(model string) error                  { return nil }
+func (stubConversation) Continue(ctx context.Context) ([]byte, error) { return nil, nil }
+func (stubConversation) AskWithTools(ctx context.Context, question string, tools []ToolDefinition, executor ToolExecutor, maxToolIterations int) ([]byte, error) {
+	return nil, nil
+}
+func (stubConversation) Config() *ConversationConfig { return &ConversationConfig{} }
+func (stubConversation) History() []*Message         { return nil }
+func (stubConversation) LastResult() *AskResult      { return nil }
+
+func TestLRUConversationStoreSetDoesNotPanic(t *testing.T) {
+	store := newLRUConversationStore(10)
+
+	if err := store.Set("a", stubConversation{}, time.Minute); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	if _, ok := store.Get("a"); !ok {
+		t.Fatal("Get(a) = false, want true")
+	}
+}
+
+func TestLRUConversationStoreListPrunesEvicted(t *testing.T) {
+	store := newLRUConversationStore(1)
+
+	if err := store.Set("a", stubConversation{}, time.Minute); err != nil {
+		t.Fatalf("Set(a) returned error: %v", err)
+	}
+	if err := store.Set("b", stubConversation{}, time.Minute); err != nil {
+		t.Fatalf("Set(b) returned error: %v", err)
+	}
+
+	ids, err := store.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("List() = %v, want [b] (a should have been evicted and pruned)", ids)
+	}
+}
@@ -0,0 +1,285 @@
+package chatgptclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AskStreamChunk is a single increment of a streamed Ask response.
+type AskStreamChunk struct {
+	// Delta is the incremental text produced since the previous chunk.
+	Delta string `json:"delta"`
+	// FinishReason is non-empty on the final chunk (e.g. "stop", "length").
+	FinishReason string `json:"finish_reason,omitempty"`
+	// PromptTokens, CompletionTokens and TotalTokens are cumulative usage,
+	// populated once the final chunk of the stream arrives.
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	TotalTokens      int `json:"total_tokens,omitempty"`
+	// Err is set on the terminal chunk if the stream failed; the channel
+	// is closed right after.
+	Err error `json:"-"`
+}
+
+type streamUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type chatCompletionStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *streamUsage `json:"usage"`
+}
+
+type completionStreamChunk struct {
+	Choices []struct {
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *streamUsage `json:"usage"`
+}
+
+// AskStream is the streaming counterpart of Ask: instead of buffering the
+// whole reply, it consumes the OpenAI SSE endpoint for both
+// chat/completions and completions and emits one AskStreamChunk per delta.
+//
+// Cancellation is controlled through AskConfig.Context: once it is
+// cancelled the stream is torn down and a terminal chunk carrying the
+// context error is emitted.
+//
+// Tools and Attachments aren't supported here (the SSE delta schema has no
+// room for vision content-parts or tool_calls); use Ask instead, which
+// routes those through askChatRaw.
+func (c *client) AskStream(cfg *AskConfig) (<-chan AskStreamChunk, error) {
+	if len(cfg.Tools) > 0 || hasAttachments(cfg.Messages) {
+		return nil, fmt.Errorf("AskStream does not support Tools or Attachments; use Ask instead")
+	}
+
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	prompt := cfg.Prompt
+	if len(cfg.Messages) > 0 {
+		built, err := buildPromptFromMessages(c.cfg.Tokenizer, cfg.Model, cfg.PromptContext, cfg.Messages, c.cfg.MaxResponseTokens)
+		if err != nil {
+			return nil, err
+		}
+		prompt = string(built)
+	}
+
+	promptTokens, err := c.cfg.Tokenizer.CountTokens(cfg.Model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	isChat := false
+	path := "/v1/completions"
+	payload := map[string]interface{}{
+		"model":      cfg.Model,
+		"prompt":     prompt,
+		"max_tokens": calculationPromptMaxTokens(promptTokens, modelContextWindow(cfg.Model), c.cfg.MaxResponseTokens),
+		"stream":     true,
+	}
+
+	if isChatModel(cfg.Model) {
+		isChat = true
+		path = "/v1/chat/completions"
+
+		messageTokens := 0
+		messages := []map[string]string{}
+		for _, msg := range cfg.Messages {
+			tokens, err := c.cfg.Tokenizer.CountTokens(cfg.Model, msg.Text)
+			if err != nil {
+				return nil, err
+			}
+
+			messageTokens += tokens
+			messages = append(messages, map[string]string{
+				"role":    msg.Role,
+				"content": msg.Text,
+			})
+		}
+
+		payload = map[string]interface{}{
+			"model":       cfg.Model,
+			"messages":    messages,
+			"max_tokens":  calculationPromptMaxTokens(messageTokens, modelContextWindow(cfg.Model), c.cfg.MaxResponseTokens),
+			"temperature": 0.1,
+			"stream":      true,
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.APIServer+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("openai stream request failed with status %d", resp.StatusCode)
+	}
+
+	ch := make(chan AskStreamChunk)
+	go streamSSE(ctx, resp.Body, isChat, ch)
+
+	return ch, nil
+}
+
+func streamSSE(ctx context.Context, body io.ReadCloser, isChat bool, ch chan<- AskStreamChunk) {
+	defer close(ch)
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			ch <- AskStreamChunk{Err: ctx.Err()}
+			return
+		default:
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return
+		}
+
+		chunk, err := parseStreamChunk(data, isChat)
+		if err != nil {
+			ch <- AskStreamChunk{Err: err}
+			return
+		}
+
+		ch <- chunk
+	}
+
+	if err := scanner.Err(); err != nil {
+		ch <- AskStreamChunk{Err: err}
+	}
+}
+
+func parseStreamChunk(data string, isChat bool) (chunk AskStreamChunk, err error) {
+	if isChat {
+		var raw chatCompletionStreamChunk
+		if err = json.Unmarshal([]byte(data), &raw); err != nil {
+			return chunk, err
+		}
+		if len(raw.Choices) > 0 {
+			chunk.Delta = raw.Choices[0].Delta.Content
+			chunk.FinishReason = raw.Choices[0].FinishReason
+		}
+		if raw.Usage != nil {
+			chunk.PromptTokens = raw.Usage.PromptTokens
+			chunk.CompletionTokens = raw.Usage.CompletionTokens
+			chunk.TotalTokens = raw.Usage.TotalTokens
+		}
+		return chunk, nil
+	}
+
+	var raw completionStreamChunk
+	if err = json.Unmarshal([]byte(data), &raw); err != nil {
+		return chunk, err
+	}
+	if len(raw.Choices) > 0 {
+		chunk.Delta = raw.Choices[0].Text
+		chunk.FinishReason = raw.Choices[0].FinishReason
+	}
+	if raw.Usage != nil {
+		chunk.PromptTokens = raw.Usage.PromptTokens
+		chunk.CompletionTokens = raw.Usage.CompletionTokens
+		chunk.TotalTokens = raw.Usage.TotalTokens
+	}
+	return chunk, nil
+}
+
+// AskStream is the streaming counterpart of Ask: it replays the
+// conversation's history same as Ask does, but emits the reply
+// incrementally. The fully assembled reply is appended to the
+// conversation's history once the stream completes, exactly as Ask does,
+// so follow-up turns see it.
+func (cv *conversation) AskStream(cfg *AskConfig) (<-chan AskStreamChunk, error) {
+	if len(cfg.Tools) > 0 || hasAttachments(cv.history()) {
+		return nil, fmt.Errorf("conversation(id: %s): AskStream does not support Tools or Attachments; use Ask instead", cv.cfg.ID)
+	}
+
+	question := cfg.Prompt
+	cv.messages.Push(&Message{Role: "user", Text: question})
+
+	cfg.Model = cv.cfg.Model
+	cfg.Messages = cv.history()
+
+	out, err := cv.client.AskStream(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	relay := make(chan AskStreamChunk)
+	go func() {
+		defer close(relay)
+
+		var full strings.Builder
+		result := &AskResult{}
+		failed := false
+		for chunk := range out {
+			if chunk.Err != nil {
+				failed = true
+			} else {
+				full.WriteString(chunk.Delta)
+			}
+			if chunk.FinishReason != "" {
+				result.FinishReason = chunk.FinishReason
+				result.PromptTokens = chunk.PromptTokens
+				result.CompletionTokens = chunk.CompletionTokens
+				result.TotalTokens = chunk.TotalTokens
+			}
+			relay <- chunk
+		}
+		if failed {
+			return
+		}
+
+		assistantMessage := &Message{Role: "assistant", Text: full.String(), IsChatGPT: true}
+		cv.messages.Push(assistantMessage)
+		cv.lastAssistantMessage = assistantMessage
+		cv.lastResult = result
+		cv.continuations = 0
+
+		if err := cv.persist(); err != nil {
+			relay <- AskStreamChunk{Err: err}
+		}
+	}()
+
+	return relay, nil
+}
@@ -0,0 +1,39 @@
+package chatgptclient
+
+import "testing"
+
+func TestParseStreamChunkChat(t *testing.T) {
+	data := `{"choices":[{"delta":{"content":"hello"},"finish_reason":""}]}`
+
+	chunk, err := parseStreamChunk(data, true)
+	if err != nil {
+		t.Fatalf("parseStreamChunk returned error: %v", err)
+	}
+	if chunk.Delta != "hello" {
+		t.Errorf("chunk.Delta = %q, want %q", chunk.Delta, "hello")
+	}
+}
+
+func TestParseStreamChunkCompletion(t *testing.T) {
+	data := `{"choices":[{"text":"hello","finish_reason":"stop"}],"usage":{"total_tokens":5}}`
+
+	chunk, err := parseStreamChunk(data, false)
+	if err != nil {
+		t.Fatalf("parseStreamChunk returned error: %v", err)
+	}
+	if chunk.Delta != "hello" || chunk.FinishReason != "stop" || chunk.TotalTokens != 5 {
+		t.Errorf("parseStreamChunk(%q) = %+v, want Delta=hello FinishReason=stop TotalTokens=5", data, chunk)
+	}
+}
+
+func TestAskStreamRejectsToolsAndAttachments(t *testing.T) {
+	c := &client{cfg: &Config{}}
+
+	if _, err := c.AskStream(&AskConfig{Tools: []ToolDefinition{{Type: "function"}}}); err == nil {
+		t.Error("AskStream with Tools set: got nil error, want an error")
+	}
+
+	if _, err := c.AskStream(&AskConfig{Messages: []*Message{{Attachments: []*Attachment{{Type: AttachmentTypeImageURL}}}}}); err == nil {
+		t.Error("AskStream with Attachments set: got nil error, want an error")
+	}
+}
@@ -0,0 +1,73 @@
+package chatgptclient
+
+import (
+	"github.com/pkoukk/tiktoken-go"
+
+	openai "github.com/go-zoox/openai-client"
+)
+
+// Tokenizer counts how many tokens a piece of text consumes for a given
+// model. Users can plug a custom implementation via Config.Tokenizer.
+type Tokenizer interface {
+	CountTokens(model, text string) (int, error)
+}
+
+// modelContextWindow returns the total context window (prompt + response)
+// for a model, falling back to the gpt-3.5-turbo window for unknown ones.
+func modelContextWindow(model string) int {
+	switch model {
+	case openai.ModelGPT_4, openai.ModelGPT_4_0314:
+		return 8192
+	case openai.ModelGPT_4_32K, openai.ModelGPT_4_32K_0314:
+		return 32768
+	case openai.ModelGPT3_5Turbo_16K, openai.ModelGPT3_5Turbo_16K_0613:
+		return 16384
+	case openai.ModelGPT_4_1106_Preview, openai.ModelGPT_4_Turbo:
+		return 128000
+	case "text-davinci-003", "text-davinci-002":
+		return 4097
+	default:
+		return 4096
+	}
+}
+
+// isChatModel reports whether model is served by /v1/chat/completions
+// rather than the legacy /v1/completions endpoint. Attachments and Tools
+// only work against chat-capable models, so this covers the whole
+// gpt-3.5-turbo/gpt-4 family, not just gpt-3.5-turbo itself.
+func isChatModel(model string) bool {
+	switch model {
+	case openai.ModelGPT3_5Turbo, openai.ModelGPT3_5Turbo0301,
+		openai.ModelGPT3_5Turbo_16K, openai.ModelGPT3_5Turbo_16K_0613,
+		openai.ModelGPT_4, openai.ModelGPT_4_0314,
+		openai.ModelGPT_4_32K, openai.ModelGPT_4_32K_0314,
+		openai.ModelGPT_4_1106_Preview, openai.ModelGPT_4_Turbo:
+		return true
+	default:
+		return false
+	}
+}
+
+type tiktokenTokenizer struct{}
+
+// newDefaultTokenizer returns the Tokenizer used when Config.Tokenizer is
+// left unset: real BPE counting via tiktoken-go, keyed by model so
+// gpt-3.5-turbo, gpt-4 and the legacy text-davinci-* models all use their
+// correct encoding.
+func newDefaultTokenizer() Tokenizer {
+	return &tiktokenTokenizer{}
+}
+
+func (t *tiktokenTokenizer) CountTokens(model, text string) (int, error) {
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		// unknown model: cl100k_base is the closest approximation
+		// tiktoken-go ships for anything it doesn't recognize by name.
+		enc, err = tiktoken.GetEncoding("cl100k_base")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(enc.Encode(text, nil, nil)), nil
+}
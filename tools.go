@@ -0,0 +1,123 @@
+package chatgptclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultMaxToolIterations bounds how many model<->tool round-trips
+// Conversation.AskWithTools makes before giving up.
+const DefaultMaxToolIterations = 8
+
+// ToolDefinition describes a callable function in OpenAI's function-calling
+// schema, passed via AskConfig.Tools.
+type ToolDefinition struct {
+	Type     string             `json:"type"`
+	Function ToolFunctionSchema `json:"function"`
+}
+
+// ToolFunctionSchema is the function half of a ToolDefinition.
+type ToolFunctionSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// toolChoicePayload serializes AskConfig.ToolChoice for the tool_choice
+// field: "auto", "none" and "required" are sent as-is, anything else is
+// treated as a function name and wrapped in the object form the API
+// requires to force that specific function.
+func toolChoicePayload(choice string) interface{} {
+	switch choice {
+	case "auto", "none", "required":
+		return choice
+	default:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": choice},
+		}
+	}
+}
+
+// ToolCall is a single function call the model asked to make.
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the function half of a ToolCall.
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolExecutor dispatches a ToolCall to its implementation and returns the
+// result to hand back to the model as a tool message.
+type ToolExecutor interface {
+	Execute(ctx context.Context, call ToolCall) (result string, err error)
+}
+
+// AskWithTools calls the model with the given tools, and for every
+// tool_calls response dispatches each call to executor, appends the tool
+// results as "tool" role messages, and recalls the model, until a final
+// assistant message arrives or MaxToolIterations is hit.
+func (cv *conversation) AskWithTools(ctx context.Context, question string, tools []ToolDefinition, executor ToolExecutor, maxToolIterations int) ([]byte, error) {
+	if maxToolIterations == 0 {
+		maxToolIterations = DefaultMaxToolIterations
+	}
+
+	cv.messages.Push(&Message{Role: "user", Text: question})
+
+	for i := 0; i < maxToolIterations; i++ {
+		result := &AskResult{}
+		answer, err := cv.client.Ask(&AskConfig{
+			Model:         cv.cfg.Model,
+			Messages:      cv.history(),
+			Context:       ctx,
+			Tools:         tools,
+			PromptContext: cv.cfg.Context,
+			Result:        result,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.ToolCalls) == 0 {
+			assistantMessage := &Message{Role: "assistant", Text: string(answer), IsChatGPT: true}
+			cv.messages.Push(assistantMessage)
+			cv.lastAssistantMessage = assistantMessage
+			cv.lastResult = result
+			cv.continuations = 0
+
+			if err := cv.persist(); err != nil {
+				return nil, err
+			}
+
+			return answer, nil
+		}
+
+		cv.messages.Push(&Message{Role: "assistant", Text: string(answer), ToolCalls: result.ToolCalls})
+
+		for _, call := range result.ToolCalls {
+			output, err := executor.Execute(ctx, call)
+			if err != nil {
+				output = fmt.Sprintf("error: %s", err)
+			}
+
+			cv.messages.Push(&Message{
+				Role:       "tool",
+				Text:       output,
+				ToolCallID: call.ID,
+				Name:       call.Function.Name,
+			})
+		}
+
+		if err := cv.persist(); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("conversation(id: %s): reached MaxToolIterations(%d) without a final reply", cv.cfg.ID, maxToolIterations)
+}